@@ -0,0 +1,129 @@
+// Package auth provides delegated bearer-token authentication and
+// authorization for HTTP handlers, modeled on the same
+// TokenReview/SubjectAccessReview flow kube-apiserver uses for its
+// "delegated auth" flags.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Config describes how incoming requests should be authenticated and
+// authorized before they reach the wrapped handler.
+type Config struct {
+	// Client is used to submit TokenReviews/SubjectAccessReviews. When nil,
+	// only StaticTokens can authenticate and no authorization check is made.
+	Client kubernetes.Interface
+
+	// StaticTokens, when non-nil, authenticates a token directly against
+	// this table (loaded via LoadStaticTokens) before falling back to a
+	// TokenReview against Client.
+	StaticTokens map[string]authenticationv1.UserInfo
+}
+
+// Middleware returns an http middleware that authenticates the bearer token
+// on each request against cfg.StaticTokens, falling back to a TokenReview
+// when cfg.Client is set, then authorizes the resulting user via a
+// SubjectAccessReview against the request's own URL path as a non-resource
+// URL. Requests without a valid, authorized token are rejected with 401 or
+// 403. With a nil cfg.Client the SubjectAccessReview step is skipped, so any
+// static token is allowed through.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+
+			user, ok := cfg.StaticTokens[token]
+			if !ok && cfg.Client != nil {
+				var err error
+				user, ok, err = authenticate(r.Context(), cfg.Client, token)
+				if err != nil {
+					http.Error(w, "authentication error", http.StatusInternalServerError)
+					return
+				}
+			}
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if cfg.Client != nil {
+				allowed, err := authorize(r.Context(), cfg.Client, user, r.URL.Path, r.Method)
+				if err != nil {
+					http.Error(w, "authorization error", http.StatusInternalServerError)
+					return
+				}
+				if !allowed {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(auth, prefix)
+}
+
+func authenticate(ctx context.Context, client kubernetes.Interface, token string) (authenticationv1.UserInfo, bool, error) {
+	if token == "" {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+
+	review, err := client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsUnauthorized(err) {
+			return authenticationv1.UserInfo{}, false, nil
+		}
+		return authenticationv1.UserInfo{}, false, err
+	}
+
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+
+	return review.Status.User, true, nil
+}
+
+func authorize(ctx context.Context, client kubernetes.Interface, user authenticationv1.UserInfo, path, verb string) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	review, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: strings.ToLower(verb),
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
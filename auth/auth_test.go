@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func tokenReviewReactor(authenticated bool, user authenticationv1.UserInfo) ktesting.ReactionFunc {
+	return func(action ktesting.Action) (bool, runtime.Object, error) {
+		review := action.(ktesting.CreateAction).GetObject().(*authenticationv1.TokenReview).DeepCopy()
+		review.Status = authenticationv1.TokenReviewStatus{Authenticated: authenticated, User: user}
+		return true, review, nil
+	}
+}
+
+func subjectAccessReviewReactor(allowed bool) ktesting.ReactionFunc {
+	return func(action ktesting.Action) (bool, runtime.Object, error) {
+		sar := action.(ktesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview).DeepCopy()
+		sar.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: allowed}
+		return true, sar, nil
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	adminUser := authenticationv1.UserInfo{Username: "admin"}
+
+	cases := []struct {
+		name           string
+		cfg            func(client *fake.Clientset) Config
+		authHeader     string
+		reactTokenAuth bool
+		reactSARAllow  bool
+		wantStatus     int
+	}{
+		{
+			name:       "no token",
+			cfg:        func(_ *fake.Clientset) Config { return Config{} },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "static token, no client configured skips authorization",
+			cfg: func(_ *fake.Clientset) Config {
+				return Config{StaticTokens: map[string]authenticationv1.UserInfo{"tok": adminUser}}
+			},
+			authHeader: "Bearer tok",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "static token, SAR denies",
+			cfg: func(client *fake.Clientset) Config {
+				return Config{Client: client, StaticTokens: map[string]authenticationv1.UserInfo{"tok": adminUser}}
+			},
+			authHeader:    "Bearer tok",
+			reactSARAllow: false,
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name: "static token, SAR allows",
+			cfg: func(client *fake.Clientset) Config {
+				return Config{Client: client, StaticTokens: map[string]authenticationv1.UserInfo{"tok": adminUser}}
+			},
+			authHeader:    "Bearer tok",
+			reactSARAllow: true,
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name: "unknown token falls back to TokenReview, unauthenticated",
+			cfg: func(client *fake.Clientset) Config {
+				return Config{Client: client}
+			},
+			authHeader:     "Bearer unknown",
+			reactTokenAuth: false,
+			wantStatus:     http.StatusUnauthorized,
+		},
+		{
+			name: "unknown token falls back to TokenReview, authenticated and allowed",
+			cfg: func(client *fake.Clientset) Config {
+				return Config{Client: client}
+			},
+			authHeader:     "Bearer unknown",
+			reactTokenAuth: true,
+			reactSARAllow:  true,
+			wantStatus:     http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "tokenreviews", tokenReviewReactor(tc.reactTokenAuth, adminUser))
+			client.PrependReactor("create", "subjectaccessreviews", subjectAccessReviewReactor(tc.reactSARAllow))
+
+			handler := Middleware(tc.cfg(client))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.authHeader != "" {
+				req.Header.Set("Authorization", tc.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+// LoadStaticTokens parses a static token file in the same
+// "token,user,uid,group1,group2" CSV format kube-apiserver's
+// --token-auth-file accepts, for simple bearer-token auth that doesn't
+// require a round trip to the API server.
+func LoadStaticTokens(path string) (map[string]authenticationv1.UserInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing static token file %s: %w", path, err)
+	}
+
+	tokens := make(map[string]authenticationv1.UserInfo, len(records))
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected at least token,user", path, i+1)
+		}
+
+		user := authenticationv1.UserInfo{Username: record[1]}
+		if len(record) > 2 {
+			user.UID = record[2]
+		}
+		if len(record) > 3 {
+			user.Groups = record[3:]
+		}
+		tokens[record[0]] = user
+	}
+
+	return tokens, nil
+}
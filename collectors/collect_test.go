@@ -0,0 +1,47 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collect drains everything c.Collect emits into dto.Metrics, keyed by
+// *prometheus.Desc so tests can assert on individual series (using the
+// collector's own private Desc fields) without caring about Describe/Collect
+// ordering.
+func collect(t *testing.T, c prometheus.Collector) map[*prometheus.Desc][]*dto.Metric {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	out := map[*prometheus.Desc][]*dto.Metric{}
+
+	go func() {
+		defer close(done)
+		for m := range ch {
+			var pb dto.Metric
+			if err := m.Write(&pb); err != nil {
+				t.Errorf("writing metric: %v", err)
+				continue
+			}
+			out[m.Desc()] = append(out[m.Desc()], &pb)
+		}
+	}()
+
+	c.Collect(ch)
+	close(ch)
+	<-done
+
+	return out
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package collectors
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Collector is implemented by every resource-specific collector in this
+// package. It's exactly prometheus.Collector, aliased so registry code reads
+// in terms of kube-svc-metrics' own vocabulary rather than a vendor type.
+type Collector = prometheus.Collector
+
+// Name identifies a collector that can be toggled via --collectors.
+type Name string
+
+const (
+	Service       Name = "service"
+	Ingress       Name = "ingress"
+	EndpointSlice Name = "endpointslice"
+)
+
+// AllNames are the collectors known to this package. Collectors are built
+// and registered in the order given by Options.Enabled, not this order.
+var AllNames = []Name{Service, Ingress, EndpointSlice}
@@ -0,0 +1,72 @@
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// endpointSliceServiceLabel is the well-known label EndpointSlices carry
+// pointing back at the Service they belong to.
+// https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/#ownership
+const endpointSliceServiceLabel = "kubernetes.io/service-name"
+
+// EndpointSliceCollector exposes readiness/terminating endpoint counts per
+// Service from its discovery.k8s.io/v1 EndpointSlices, one series per
+// EndpointSlice labelled with the owning Service; series are not summed
+// across a Service's slices.
+type EndpointSliceCollector struct {
+	endpointSliceIndexer cache.Indexer
+
+	readyEndpoints       *prometheus.Desc
+	terminatingEndpoints *prometheus.Desc
+}
+
+// NewEndpointSliceCollector returns an EndpointSliceCollector backed by
+// endpointSliceIndexer.
+func NewEndpointSliceCollector(endpointSliceIndexer cache.Indexer) *EndpointSliceCollector {
+	return &EndpointSliceCollector{
+		endpointSliceIndexer: endpointSliceIndexer,
+
+		readyEndpoints: prometheus.NewDesc("kube_endpointslice_ready_endpoints",
+			"Number of ready endpoints per Service, one series per EndpointSlice.",
+			[]string{"namespace", "service", "endpointslice"}, nil,
+		),
+		terminatingEndpoints: prometheus.NewDesc("kube_endpointslice_terminating_endpoints",
+			"Number of terminating endpoints per Service, one series per EndpointSlice.",
+			[]string{"namespace", "service", "endpointslice"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *EndpointSliceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.readyEndpoints
+	ch <- c.terminatingEndpoints
+}
+
+// Collect implements prometheus.Collector.
+func (c *EndpointSliceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, obj := range c.endpointSliceIndexer.List() {
+		c.collectEndpointSlice(ch, obj.(*discoveryv1.EndpointSlice))
+	}
+}
+
+func (c *EndpointSliceCollector) collectEndpointSlice(ch chan<- prometheus.Metric, eps *discoveryv1.EndpointSlice) {
+	service := eps.Labels[endpointSliceServiceLabel]
+
+	var ready, terminating float64
+	for _, ep := range eps.Endpoints {
+		if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+			ready++
+		}
+		if ep.Conditions.Terminating != nil && *ep.Conditions.Terminating {
+			terminating++
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.readyEndpoints, prometheus.GaugeValue, ready,
+		eps.Namespace, service, eps.Name)
+	ch <- prometheus.MustNewConstMetric(c.terminatingEndpoints, prometheus.GaugeValue, terminating,
+		eps.Namespace, service, eps.Name)
+}
@@ -0,0 +1,53 @@
+package collectors
+
+import (
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestEndpointSliceCollectorReadyCounts(t *testing.T) {
+	eps := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "my-svc-abc12",
+			Labels:    map[string]string{endpointSliceServiceLabel: "my-svc"},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(true)}},
+			{Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(false)}},
+			// Ready == nil is documented as "unknown state", which consumers
+			// should interpret as ready.
+			{Conditions: discoveryv1.EndpointConditions{}},
+			{Conditions: discoveryv1.EndpointConditions{Terminating: boolPtr(true)}},
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(eps); err != nil {
+		t.Fatalf("indexer.Add: %v", err)
+	}
+
+	c := NewEndpointSliceCollector(indexer)
+	metrics := collect(t, c)
+
+	ready := metrics[c.readyEndpoints]
+	if len(ready) != 1 {
+		t.Fatalf("got %d kube_endpointslice_ready_endpoints series, want 1", len(ready))
+	}
+	if got, want := ready[0].GetGauge().GetValue(), float64(3); got != want {
+		t.Errorf("ready endpoints = %v, want %v (true + unset Ready both count as ready)", got, want)
+	}
+
+	terminating := metrics[c.terminatingEndpoints]
+	if len(terminating) != 1 {
+		t.Fatalf("got %d kube_endpointslice_terminating_endpoints series, want 1", len(terminating))
+	}
+	if got, want := terminating[0].GetGauge().GetValue(), float64(1); got != want {
+		t.Errorf("terminating endpoints = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IngressCollector exposes kube_ingress_* metric families for every
+// networking.k8s.io/v1 Ingress in the informer cache.
+type IngressCollector struct {
+	ingressIndexer cache.Indexer
+
+	info                      *prometheus.Desc
+	statusLoadBalancerIngress *prometheus.Desc
+}
+
+// NewIngressCollector returns an IngressCollector backed by ingressIndexer.
+func NewIngressCollector(ingressIndexer cache.Indexer) *IngressCollector {
+	return &IngressCollector{
+		ingressIndexer: ingressIndexer,
+
+		info: prometheus.NewDesc("kube_ingress_info",
+			"Information about ingress. One series per rule/path/backend combination.",
+			[]string{"namespace", "ingress", "class", "host", "path", "service"}, nil,
+		),
+		statusLoadBalancerIngress: prometheus.NewDesc("kube_ingress_status_load_balancer_ingress",
+			"Ingress load balancer ingress status. One series per ingress point.",
+			[]string{"namespace", "ingress", "ip", "hostname"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *IngressCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.statusLoadBalancerIngress
+}
+
+// Collect implements prometheus.Collector.
+func (c *IngressCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, obj := range c.ingressIndexer.List() {
+		c.collectIngress(ch, obj.(*networkingv1.Ingress))
+	}
+}
+
+func (c *IngressCollector) collectIngress(ch chan<- prometheus.Metric, ing *networkingv1.Ingress) {
+	class := ""
+	if ing.Spec.IngressClassName != nil {
+		class = *ing.Spec.IngressClassName
+	}
+
+	if len(ing.Spec.Rules) == 0 {
+		// A rule-less Ingress routes everything through Spec.DefaultBackend;
+		// emit one series for it so the ingress still shows up in
+		// kube_ingress_info rather than vanishing from this metric family.
+		service := ""
+		if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
+			service = ing.Spec.DefaultBackend.Service.Name
+		}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+			ing.Namespace, ing.Name, class, "", "", service)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+				ing.Namespace, ing.Name, class, rule.Host, "", "")
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			service := ""
+			if path.Backend.Service != nil {
+				service = path.Backend.Service.Name
+			}
+			ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+				ing.Namespace, ing.Name, class, rule.Host, path.Path, service)
+		}
+	}
+
+	for _, ingress := range ing.Status.LoadBalancer.Ingress {
+		ch <- prometheus.MustNewConstMetric(c.statusLoadBalancerIngress, prometheus.GaugeValue, 1,
+			ing.Namespace, ing.Name, ingress.IP, ingress.Hostname)
+	}
+}
@@ -0,0 +1,80 @@
+package collectors
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestIngressCollectorDefaultBackendOnly(t *testing.T) {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "catch-all"},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{Name: "fallback"},
+			},
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(ing); err != nil {
+		t.Fatalf("indexer.Add: %v", err)
+	}
+
+	c := NewIngressCollector(indexer)
+	metrics := collect(t, c)
+
+	info := metrics[c.info]
+	if len(info) != 1 {
+		t.Fatalf("got %d kube_ingress_info series for a rule-less ingress, want 1", len(info))
+	}
+	if got, want := labelValue(info[0], "service"), "fallback"; got != want {
+		t.Errorf("service label = %q, want %q", got, want)
+	}
+	if got, want := labelValue(info[0], "host"), ""; got != want {
+		t.Errorf("host label = %q, want %q", got, want)
+	}
+}
+
+func TestIngressCollectorRules(t *testing.T) {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{Path: "/", Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: "web"},
+								}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(ing); err != nil {
+		t.Fatalf("indexer.Add: %v", err)
+	}
+
+	c := NewIngressCollector(indexer)
+	metrics := collect(t, c)
+
+	info := metrics[c.info]
+	if len(info) != 1 {
+		t.Fatalf("got %d kube_ingress_info series, want 1", len(info))
+	}
+	if got, want := labelValue(info[0], "host"), "example.com"; got != want {
+		t.Errorf("host label = %q, want %q", got, want)
+	}
+	if got, want := labelValue(info[0], "service"), "web"; got != want {
+		t.Errorf("service label = %q, want %q", got, want)
+	}
+}
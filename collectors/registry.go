@@ -0,0 +1,127 @@
+package collectors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Options configures which collectors a Registry builds and how their
+// informers are scoped.
+type Options struct {
+	// Namespace restricts every collector's informer to a single
+	// namespace; the empty string watches all namespaces.
+	Namespace string
+
+	// Enabled lists the collectors to build, by Name.
+	Enabled []Name
+
+	ServiceLabelKeys      []string
+	ServiceAnnotationKeys []string
+}
+
+// Registry owns the informer factory backing every enabled collector and
+// starts/syncs them together.
+type Registry struct {
+	factory    informers.SharedInformerFactory
+	collectors []Collector
+	synced     []cache.InformerSynced
+	indexers   map[Name]cache.Indexer
+
+	mu           sync.RWMutex
+	lastSyncTime time.Time
+}
+
+// NewRegistry builds a Registry for clientset according to opts. Collectors
+// not named in opts.Enabled are skipped and their informers are never
+// created, so disabling a collector also disables its API watch.
+func NewRegistry(clientset kubernetes.Interface, opts Options) (*Registry, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 5*time.Minute, informers.WithNamespace(opts.Namespace))
+
+	r := &Registry{factory: factory, indexers: map[Name]cache.Indexer{}}
+
+	for _, name := range opts.Enabled {
+		switch name {
+		case Service:
+			informer := factory.Core().V1().Services().Informer()
+			r.add(Service, NewServiceCollector(informer.GetIndexer(), opts.ServiceLabelKeys, opts.ServiceAnnotationKeys), informer)
+		case Ingress:
+			informer := factory.Networking().V1().Ingresses().Informer()
+			r.add(Ingress, NewIngressCollector(informer.GetIndexer()), informer)
+		case EndpointSlice:
+			informer := factory.Discovery().V1().EndpointSlices().Informer()
+			r.add(EndpointSlice, NewEndpointSliceCollector(informer.GetIndexer()), informer)
+		default:
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Registry) add(name Name, c Collector, informer cache.SharedIndexInformer) {
+	r.collectors = append(r.collectors, c)
+	r.synced = append(r.synced, informer.HasSynced)
+	r.indexers[name] = informer.GetIndexer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { r.touchSync() },
+		UpdateFunc: func(interface{}, interface{}) { r.touchSync() },
+		DeleteFunc: func(interface{}) { r.touchSync() },
+	})
+}
+
+func (r *Registry) touchSync() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSyncTime = time.Now()
+}
+
+// LastSyncTime returns the time of the most recent add/update/delete event
+// observed across every enabled informer. It's the zero Time until the
+// first event arrives.
+func (r *Registry) LastSyncTime() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastSyncTime
+}
+
+// HasSynced reports whether every enabled collector's informer has
+// completed its initial list.
+func (r *Registry) HasSynced() bool {
+	for _, synced := range r.synced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Indexer returns the indexer backing the named collector, and whether that
+// collector is enabled. Useful for callers (like the /sd endpoint) that need
+// direct read access to a specific informer's cache.
+func (r *Registry) Indexer(name Name) (cache.Indexer, bool) {
+	indexer, ok := r.indexers[name]
+	return indexer, ok
+}
+
+// Collectors returns every enabled collector, ready to be passed to a
+// prometheus.Registerer.
+func (r *Registry) Collectors() []Collector {
+	return r.collectors
+}
+
+// Start runs every enabled collector's informer until stop is closed.
+func (r *Registry) Start(stop <-chan struct{}) {
+	r.factory.Start(stop)
+}
+
+// WaitForCacheSync blocks until every enabled collector's informer has
+// synced, or stop is closed, returning false in the latter case.
+func (r *Registry) WaitForCacheSync(stop <-chan struct{}) bool {
+	return cache.WaitForCacheSync(stop, r.synced...)
+}
@@ -0,0 +1,163 @@
+// Package collectors implements Prometheus collectors for Kubernetes
+// resources, following the metric family conventions established by
+// kube-state-metrics.
+package collectors
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ServiceCollector exposes kube_service_* metric families for every Service
+// in the informer cache, not just LoadBalancer services.
+type ServiceCollector struct {
+	serviceIndexer cache.Indexer
+	labelKeys      []string
+	annotationKeys []string
+
+	info                      *prometheus.Desc
+	specExternalIP            *prometheus.Desc
+	statusLoadBalancerIngress *prometheus.Desc
+	specPort                  *prometheus.Desc
+	labels                    *prometheus.Desc
+	annotations               *prometheus.Desc
+	created                   *prometheus.Desc
+}
+
+// NewServiceCollector returns a ServiceCollector backed by serviceIndexer.
+// labelKeys and annotationKeys are the allow-listed Service label/annotation
+// keys to expose via kube_service_labels/kube_service_annotations.
+func NewServiceCollector(serviceIndexer cache.Indexer, labelKeys, annotationKeys []string) *ServiceCollector {
+	return &ServiceCollector{
+		serviceIndexer: serviceIndexer,
+		labelKeys:      labelKeys,
+		annotationKeys: annotationKeys,
+
+		info: prometheus.NewDesc("kube_service_info",
+			"Information about service.",
+			[]string{"namespace", "service", "cluster_ip", "external_name", "type"}, nil,
+		),
+		specExternalIP: prometheus.NewDesc("kube_service_spec_external_ip",
+			"Service external IPs. One series per IP.",
+			[]string{"namespace", "service", "external_ip"}, nil,
+		),
+		statusLoadBalancerIngress: prometheus.NewDesc("kube_service_status_load_balancer_ingress",
+			"Service load balancer ingress status. One series per ingress point.",
+			[]string{"namespace", "service", "ip", "hostname"}, nil,
+		),
+		specPort: prometheus.NewDesc("kube_service_spec_port",
+			"Service port information. One series per declared port.",
+			[]string{"namespace", "service", "port_name", "protocol", "port", "target_port", "node_port"}, nil,
+		),
+		labels: prometheus.NewDesc("kube_service_labels",
+			"Kubernetes labels converted to Prometheus labels, limited to the configured allow-list.",
+			append([]string{"namespace", "service"}, prefixedLabelNames("label", labelKeys)...), nil,
+		),
+		annotations: prometheus.NewDesc("kube_service_annotations",
+			"Kubernetes annotations converted to Prometheus labels, limited to the configured allow-list.",
+			append([]string{"namespace", "service"}, prefixedLabelNames("annotation", annotationKeys)...), nil,
+		),
+		created: prometheus.NewDesc("kube_service_created",
+			"Unix creation timestamp of the service.",
+			[]string{"namespace", "service"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ServiceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.specExternalIP
+	ch <- c.statusLoadBalancerIngress
+	ch <- c.specPort
+	ch <- c.labels
+	ch <- c.annotations
+	ch <- c.created
+}
+
+// Collect implements prometheus.Collector.
+func (c *ServiceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, obj := range c.serviceIndexer.List() {
+		svc := obj.(*v1.Service)
+		c.collectService(ch, svc)
+	}
+}
+
+func (c *ServiceCollector) collectService(ch chan<- prometheus.Metric, svc *v1.Service) {
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+		svc.Namespace, svc.Name, svc.Spec.ClusterIP, svc.Spec.ExternalName, string(svc.Spec.Type))
+
+	for _, ip := range svc.Spec.ExternalIPs {
+		ch <- prometheus.MustNewConstMetric(c.specExternalIP, prometheus.GaugeValue, 1,
+			svc.Namespace, svc.Name, ip)
+	}
+
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		ch <- prometheus.MustNewConstMetric(c.statusLoadBalancerIngress, prometheus.GaugeValue, 1,
+			svc.Namespace, svc.Name, ingress.IP, ingress.Hostname)
+	}
+
+	for _, port := range svc.Spec.Ports {
+		ch <- prometheus.MustNewConstMetric(c.specPort, prometheus.GaugeValue, 1,
+			svc.Namespace, svc.Name, port.Name, string(port.Protocol),
+			formatInt32(port.Port), port.TargetPort.String(), formatInt32(port.NodePort))
+	}
+
+	if values, ok := labelValues(svc.Labels, c.labelKeys); ok {
+		ch <- prometheus.MustNewConstMetric(c.labels, prometheus.GaugeValue, 1,
+			append([]string{svc.Namespace, svc.Name}, values...)...)
+	}
+
+	if values, ok := labelValues(svc.Annotations, c.annotationKeys); ok {
+		ch <- prometheus.MustNewConstMetric(c.annotations, prometheus.GaugeValue, 1,
+			append([]string{svc.Namespace, svc.Name}, values...)...)
+	}
+
+	if !svc.CreationTimestamp.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.created, prometheus.GaugeValue,
+			float64(svc.CreationTimestamp.Unix()), svc.Namespace, svc.Name)
+	}
+}
+
+// labelValues looks up each key in m and returns the allow-listed values in
+// order. ok is false when keys is empty, since there's nothing to export.
+func labelValues(m map[string]string, keys []string) (values []string, ok bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	values = make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+	return values, true
+}
+
+func prefixedLabelNames(prefix string, keys []string) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = prefix + "_" + sanitizeLabelName(key)
+	}
+	return names
+}
+
+// sanitizeLabelName replaces characters that aren't valid in a Prometheus
+// label name (e.g. "/" or "." in an annotation key) with underscores.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func formatInt32(i int32) string {
+	return strconv.Itoa(int(i))
+}
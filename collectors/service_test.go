@@ -0,0 +1,74 @@
+package collectors
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestServiceCollectorLabelsAllowlist(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "web",
+			Labels:      map[string]string{"team": "infra", "ignored": "x"},
+			Annotations: map[string]string{"owner": "sre"},
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Type:      v1.ServiceTypeClusterIP,
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(svc); err != nil {
+		t.Fatalf("indexer.Add: %v", err)
+	}
+
+	c := NewServiceCollector(indexer, []string{"team"}, []string{"owner"})
+	metrics := collect(t, c)
+
+	info := metrics[c.info]
+	if len(info) != 1 {
+		t.Fatalf("got %d kube_service_info series, want 1", len(info))
+	}
+	if got, want := labelValue(info[0], "cluster_ip"), "10.0.0.1"; got != want {
+		t.Errorf("cluster_ip label = %q, want %q", got, want)
+	}
+
+	labels := metrics[c.labels]
+	if len(labels) != 1 {
+		t.Fatalf("got %d kube_service_labels series, want 1", len(labels))
+	}
+	if got, want := labelValue(labels[0], "label_team"), "infra"; got != want {
+		t.Errorf("label_team = %q, want %q", got, want)
+	}
+
+	annotations := metrics[c.annotations]
+	if len(annotations) != 1 {
+		t.Fatalf("got %d kube_service_annotations series, want 1", len(annotations))
+	}
+	if got, want := labelValue(annotations[0], "annotation_owner"), "sre"; got != want {
+		t.Errorf("annotation_owner = %q, want %q", got, want)
+	}
+}
+
+func TestServiceCollectorSkipsLabelsWithNoAllowlist(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Labels: map[string]string{"team": "infra"}},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(svc); err != nil {
+		t.Fatalf("indexer.Add: %v", err)
+	}
+
+	c := NewServiceCollector(indexer, nil, nil)
+	metrics := collect(t, c)
+
+	if got := len(metrics[c.labels]); got != 0 {
+		t.Errorf("got %d kube_service_labels series with no allow-list configured, want 0", got)
+	}
+}
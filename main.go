@@ -4,21 +4,30 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/jhorwit2/kube-svc-metrics/collectors"
+	"github.com/jhorwit2/kube-svc-metrics/health"
+	"github.com/jhorwit2/kube-svc-metrics/metrics"
+	"github.com/jhorwit2/kube-svc-metrics/server"
+	"github.com/jhorwit2/kube-svc-metrics/signals"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/util/homedir"
+	"k8s.io/klog/v2"
 )
 
 func main() {
@@ -26,15 +35,34 @@ func main() {
 	var err error
 	var config *rest.Config
 
-	if os.Getenv("USE_LOCAL") != "" {
-		var kubeconfig *string
-		if home := homedir.HomeDir(); home != "" {
-			kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
-		} else {
-			kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-		}
-		flag.Parse()
+	var kubeconfig *string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+	sdLabelKeys := flag.String("sd-meta-labels", "", "comma-separated list of service labels/annotations to expose on the /sd endpoint as meta_labels_*/meta_annotations_* (e.g. \"labels:team,annotations:owner\")")
+	serviceLabelKeys := flag.String("metric-labels-allowlist", "", "comma-separated list of service label keys to expose via kube_service_labels")
+	serviceAnnotationKeys := flag.String("metric-annotations-allowlist", "", "comma-separated list of service annotation keys to expose via kube_service_annotations")
+	leaderElect := flag.Bool("leader-elect", false, "run leader election so only one replica serves metrics at a time")
+	leaderElectNamespace := flag.String("leader-elect-resource-namespace", "default", "namespace of the leader election resource")
+	leaderElectName := flag.String("leader-elect-resource-name", "kube-svc-metrics", "name of the leader election resource")
+	leaseDuration := flag.Duration("leader-elect-lease-duration", 15*time.Second, "duration non-leaders wait before attempting to acquire leadership")
+	renewDeadline := flag.Duration("leader-elect-renew-deadline", 10*time.Second, "duration the leader renews its leadership before giving it up")
+	retryPeriod := flag.Duration("leader-elect-retry-period", 2*time.Second, "duration clients wait between leadership acquisition attempts")
+	listenAddress := flag.String("listen-address", ":8080", "address the metrics server listens on")
+	tlsCertFile := flag.String("tls-cert-file", "", "path to a PEM-encoded TLS certificate; serves plain HTTP when unset")
+	tlsKeyFile := flag.String("tls-key-file", "", "path to the PEM-encoded TLS private key matching --tls-cert-file")
+	clientCAFile := flag.String("client-ca-file", "", "path to a PEM-encoded CA bundle; when set, clients must present a certificate signed by it")
+	authTokenFile := flag.String("auth-token-file", "", "path to a static bearer token file (token,user,uid,groups CSV) for authenticating /metrics requests")
+	authKubeconfig := flag.String("auth-kubeconfig", "", "kubeconfig used to delegate /metrics authentication/authorization to the Kubernetes TokenReview/SubjectAccessReview APIs; defaults to --kubeconfig/in-cluster config when an auth option is set")
+	namespace := flag.String("namespace", "", "namespace every informer watches; empty watches all namespaces")
+	enabledCollectors := flag.String("collectors", "service,ingress,endpointslice", "comma-separated list of collectors to enable (service, ingress, endpointslice)")
+	profiling := flag.Bool("profiling", false, "serve pprof debug endpoints under /debug/pprof")
+	readyzStaleAfter := flag.Duration("readyz-stale-after", 10*time.Minute, "/readyz reports unready once this long has passed since the last informer sync event")
+	flag.Parse()
 
+	if os.Getenv("USE_LOCAL") != "" {
 		// use the current context in kubeconfig
 		config, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
 		if err != nil {
@@ -52,65 +80,242 @@ func main() {
 		panic(err.Error())
 	}
 
-	serviceListWatch := cache.NewListWatchFromClient(
-		clientset.CoreV1().RESTClient(),
-		"services",
-		v1.NamespaceAll,
-		fields.Everything(),
-	)
+	metrics.Register()
+
+	var authClient kubernetes.Interface
+	switch {
+	case *authKubeconfig != "":
+		authConfig, err := clientcmd.BuildConfigFromFlags("", *authKubeconfig)
+		if err != nil {
+			panic(err.Error())
+		}
+		authClient, err = kubernetes.NewForConfig(authConfig)
+		if err != nil {
+			panic(err.Error())
+		}
+	case *authTokenFile != "" || *clientCAFile != "":
+		// --auth-kubeconfig's help text promises it falls back to
+		// --kubeconfig/in-cluster config when another auth option is set, so
+		// reuse the config already built for clientset rather than leaving
+		// authClient nil and silently skipping SubjectAccessReview
+		// authorization.
+		authClient = clientset
+	default:
+		klog.Info("no auth options configured; /metrics will be served without authentication")
+	}
+
+	serverCfg := server.Config{
+		ListenAddress: *listenAddress,
+		TLSCertFile:   *tlsCertFile,
+		TLSKeyFile:    *tlsKeyFile,
+		ClientCAFile:  *clientCAFile,
+		AuthTokenFile: *authTokenFile,
+		AuthClient:    authClient,
+	}
+	klog.Infof("listening on %s (tls=%t, auth=%t)", serverCfg.ListenAddress, serverCfg.TLSCertFile != "", serverCfg.AuthTokenFile != "" || authClient != nil)
 
-	// never stop
-	stop := make(chan struct{})
-	defer close(stop)
-	serviceIndexer, informer := cache.NewIndexerInformer(serviceListWatch, &v1.Service{}, 5*time.Minute, cache.ResourceEventHandlerFuncs{}, cache.Indexers{})
-	go informer.Run(stop)
+	collectorNames, err := parseCollectorNames(*enabledCollectors)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	stop := signals.SetupSignalHandler()
+
+	run := func(ctx context.Context) {
+		registryOpts := collectors.Options{
+			Namespace:             *namespace,
+			Enabled:               collectorNames,
+			ServiceLabelKeys:      splitKeys(*serviceLabelKeys),
+			ServiceAnnotationKeys: splitKeys(*serviceAnnotationKeys),
+		}
+		if err := runMetricsServer(ctx, clientset, serverCfg, registryOpts, *sdLabelKeys, *readyzStaleAfter, *profiling); err != nil {
+			runtime.HandleError(err)
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := contextFromStop(stop)
 	defer cancel()
-	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
-		runtime.HandleError(errors.New("timed out waiting for caches to sync"))
+
+	if !*leaderElect {
+		run(ctx)
 		return
 	}
 
-	prometheus.MustRegister(newServiceCollector(serviceIndexer))
+	identity := string(uuid.NewUUID())
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock,
+		*leaderElectNamespace, *leaderElectName,
+		clientset.CoreV1(), clientset.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: identity},
+	)
+	if err != nil {
+		panic(err.Error())
+	}
 
-	http.Handle("/metrics", promhttp.Handler())
-	http.ListenAndServe(":8080", nil)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: *leaseDuration,
+		RenewDeadline: *renewDeadline,
+		RetryPeriod:   *retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				klog.Info("leadership lost, shutting down")
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					klog.Infof("new leader elected: %s", leader)
+				}
+			},
+		},
+	})
 }
 
-type serviceCollector struct {
-	serviceIndexer cache.Indexer
-	serviceMetric  *prometheus.Desc
+// contextFromStop adapts the signals stop channel into a context that's
+// cancelled on the same signal.
+func contextFromStop(stop <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
 }
 
-func newServiceCollector(serviceIndexer cache.Indexer) *serviceCollector {
-	return &serviceCollector{
-		serviceIndexer: serviceIndexer,
-		serviceMetric: prometheus.NewDesc("kube_service_info_extended",
-			"Extended information for services",
-			[]string{"service", "namespace", "load_balancer_ip", "uid"}, nil,
-		),
+// runMetricsServer builds the enabled collectors' informers, waits for them
+// to sync, and serves /metrics, /healthz, /readyz, /sd (when the service
+// collector is enabled) and /debug/pprof (when profiling is on) until ctx is
+// cancelled.
+func runMetricsServer(ctx context.Context, clientset kubernetes.Interface, serverCfg server.Config, registryOpts collectors.Options, sdLabelKeys string, readyzStaleAfter time.Duration, profiling bool) error {
+	collectorRegistry, err := collectors.NewRegistry(clientset, registryOpts)
+	if err != nil {
+		return err
 	}
-}
 
-func (c *serviceCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.serviceMetric
+	collectorRegistry.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if !collectorRegistry.WaitForCacheSync(syncCtx.Done()) {
+		return errors.New("timed out waiting for caches to sync")
+	}
+
+	checker := health.NewChecker(collectorRegistry, readyzStaleAfter)
+
+	promRegistry := prometheus.NewRegistry()
+	promRegistry.MustRegister(checker)
+	for _, c := range collectorRegistry.Collectors() {
+		promRegistry.MustRegister(c)
+	}
+
+	// protectedMux holds the endpoints that should require auth when
+	// --auth-token-file/--auth-kubeconfig are set: metrics, service
+	// discovery, and profiling all expose information an operator may want
+	// to restrict.
+	protectedMux := http.NewServeMux()
+	protectedMux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, promRegistry}, promhttp.HandlerOpts{}))
+
+	if serviceIndexer, ok := collectorRegistry.Indexer(collectors.Service); ok {
+		labelKeys, annotationKeys := parseMetaLabelKeys(sdLabelKeys)
+		protectedMux.Handle("/sd", newServiceDiscovery(serviceIndexer, labelKeys, annotationKeys))
+	}
+
+	if profiling {
+		protectedMux.HandleFunc("/debug/pprof/", pprof.Index)
+		protectedMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		protectedMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		protectedMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		protectedMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	protectedHandler, err := server.WrapAuth(serverCfg, protectedMux)
+	if err != nil {
+		return err
+	}
+
+	// /healthz and /readyz are liveness/readiness probes kubelet calls
+	// without credentials, so they're mounted outside protectedHandler.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", checker.Healthz)
+	mux.HandleFunc("/readyz", checker.Readyz)
+	mux.Handle("/", protectedHandler)
+
+	httpServer, err := server.New(serverCfg, mux)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("error shutting down metrics server: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(httpServer, serverCfg); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
-// Collect implements required collect function for all prometheus collectors
-func (c *serviceCollector) Collect(ch chan<- prometheus.Metric) {
-	for _, service := range c.serviceIndexer.List() {
-		svc := service.(*v1.Service)
-		if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+// parseMetaLabelKeys turns a "labels:team,annotations:owner" flag value into
+// the set of service label/annotation keys the /sd endpoint should expose.
+func parseMetaLabelKeys(flagValue string) (labelKeys, annotationKeys []string) {
+	for _, part := range strings.Split(flagValue, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
 			continue
 		}
 
-		if len(svc.Status.LoadBalancer.Ingress) < 1 {
-			// no ip so no log yet
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
 			continue
 		}
 
-		ch <- prometheus.MustNewConstMetric(c.serviceMetric, prometheus.CounterValue, 1,
-			svc.Name, svc.Namespace, svc.Status.LoadBalancer.Ingress[0].IP, string(svc.UID))
+		switch kv[0] {
+		case "labels":
+			labelKeys = append(labelKeys, kv[1])
+		case "annotations":
+			annotationKeys = append(annotationKeys, kv[1])
+		}
+	}
+	return labelKeys, annotationKeys
+}
+
+// parseCollectorNames validates and converts the --collectors flag value
+// into collectors.Name values.
+func parseCollectorNames(flagValue string) ([]collectors.Name, error) {
+	var names []collectors.Name
+	for _, key := range splitKeys(flagValue) {
+		name := collectors.Name(key)
+		valid := false
+		for _, known := range collectors.AllNames {
+			if name == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown collector %q", key)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// splitKeys splits a comma-separated flag value into a slice of trimmed,
+// non-empty keys.
+func splitKeys(flagValue string) []string {
+	var keys []string
+	for _, key := range strings.Split(flagValue, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keys = append(keys, key)
+		}
 	}
+	return keys
 }
@@ -0,0 +1,75 @@
+// Package metrics wires client-go, client-go's workqueue, and klog into the
+// default Prometheus registry so the same /metrics endpoint that exposes
+// kube_service_* also reports on the health of the controller machinery
+// itself.
+package metrics
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+var (
+	restLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "kube_svc_metrics_rest_client_request_latency_seconds",
+		Help: "Latency of Kubernetes REST client requests, by verb and URL path.",
+	}, []string{"verb", "path"})
+
+	restResult = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kube_svc_metrics_rest_client_requests_total",
+		Help: "Number of Kubernetes REST client requests, by status code, method and host.",
+	}, []string{"code", "method", "host"})
+
+	klogErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kube_svc_metrics_klog_errors_total",
+		Help: "Number of error-level log lines written via klog.",
+	})
+)
+
+// Register installs Prometheus collectors for client-go REST client
+// latency/results, client-go workqueue depth/latency, and a klog error
+// counter, and registers them all with prometheus.DefaultRegisterer.
+func Register() {
+	prometheus.MustRegister(restLatency, restResult, klogErrors)
+
+	clientmetrics.Register(clientmetrics.RegisterOpts{
+		RequestLatency: requestLatencyAdapter{},
+		RequestResult:  requestResultAdapter{},
+	})
+
+	workqueue.SetProvider(workqueueMetricsProvider{})
+
+	klog.SetOutputBySeverity("ERROR", errorCountingWriter{})
+}
+
+type requestLatencyAdapter struct{}
+
+// Observe records latency keyed on the request path alone: the query string
+// (resourceVersion, continue, timeoutSeconds, fieldSelector, ...) varies on
+// almost every List/Watch call an informer makes, and including it in the
+// label would mint a new series per call instead of per endpoint.
+func (requestLatencyAdapter) Observe(_ context.Context, verb string, u url.URL, latency time.Duration) {
+	restLatency.WithLabelValues(verb, u.Path).Observe(latency.Seconds())
+}
+
+type requestResultAdapter struct{}
+
+func (requestResultAdapter) Increment(_ context.Context, code, method, host string) {
+	restResult.WithLabelValues(code, method, host).Inc()
+}
+
+// errorCountingWriter increments klogErrors for every line klog writes at
+// error severity and forwards the line on to stderr, same as klog's default.
+type errorCountingWriter struct{}
+
+func (errorCountingWriter) Write(p []byte) (int, error) {
+	klogErrors.Inc()
+	return os.Stderr.Write(p)
+}
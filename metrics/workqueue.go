@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// workqueueMetricsProvider implements workqueue.MetricsProvider, exposing
+// the same depth/latency/retries series that client-go's controllers
+// usually report, under the kube_svc_metrics_workqueue_* namespace.
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return gaugeVec("depth").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return counterVec("adds_total").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return histogramVec("queue_duration_seconds").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return histogramVec("work_duration_seconds").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return gaugeVec("unfinished_work_seconds").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return gaugeVec("longest_running_processor_seconds").WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return counterVec("retries_total").WithLabelValues(name)
+}
+
+// vecs caches the *Vec registered for each metric name so that workqueues
+// created after the first one (e.g. on leader re-election) reuse the
+// existing series instead of panicking on duplicate registration.
+var (
+	vecsMu        sync.Mutex
+	gaugeVecs     = map[string]*prometheus.GaugeVec{}
+	counterVecs   = map[string]*prometheus.CounterVec{}
+	histogramVecs = map[string]*prometheus.HistogramVec{}
+)
+
+func gaugeVec(metric string) *prometheus.GaugeVec {
+	vecsMu.Lock()
+	defer vecsMu.Unlock()
+
+	if vec, ok := gaugeVecs[metric]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "kube_svc_metrics_workqueue",
+		Name:      metric,
+		Help:      "client-go workqueue " + metric + ", by queue name.",
+	}, []string{"name"})
+	prometheus.MustRegister(vec)
+	gaugeVecs[metric] = vec
+	return vec
+}
+
+func counterVec(metric string) *prometheus.CounterVec {
+	vecsMu.Lock()
+	defer vecsMu.Unlock()
+
+	if vec, ok := counterVecs[metric]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "kube_svc_metrics_workqueue",
+		Name:      metric,
+		Help:      "client-go workqueue " + metric + ", by queue name.",
+	}, []string{"name"})
+	prometheus.MustRegister(vec)
+	counterVecs[metric] = vec
+	return vec
+}
+
+func histogramVec(metric string) *prometheus.HistogramVec {
+	vecsMu.Lock()
+	defer vecsMu.Unlock()
+
+	if vec, ok := histogramVecs[metric]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: "kube_svc_metrics_workqueue",
+		Name:      metric,
+		Help:      "client-go workqueue " + metric + ", by queue name.",
+	}, []string{"name"})
+	prometheus.MustRegister(vec)
+	histogramVecs[metric] = vec
+	return vec
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// sdTargetGroup is the Prometheus HTTP service discovery format:
+// https://prometheus.io/docs/prometheus/latest/http_sd/
+type sdTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// serviceDiscovery serves the Prometheus HTTP SD format for LoadBalancer
+// services, built straight from the informer cache that
+// collectors.ServiceCollector also reads from.
+type serviceDiscovery struct {
+	serviceIndexer cache.Indexer
+	labelKeys      []string
+	annotationKeys []string
+}
+
+func newServiceDiscovery(serviceIndexer cache.Indexer, labelKeys, annotationKeys []string) *serviceDiscovery {
+	return &serviceDiscovery{
+		serviceIndexer: serviceIndexer,
+		labelKeys:      labelKeys,
+		annotationKeys: annotationKeys,
+	}
+}
+
+func (sd *serviceDiscovery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	groups := sd.targetGroups()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, max-age=0")
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (sd *serviceDiscovery) targetGroups() []sdTargetGroup {
+	groups := make([]sdTargetGroup, 0)
+
+	for _, obj := range sd.serviceIndexer.List() {
+		svc := obj.(*v1.Service)
+		if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+			continue
+		}
+
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			host := ingress.IP
+			if host == "" {
+				host = ingress.Hostname
+			}
+			if host == "" {
+				continue
+			}
+
+			for _, port := range svc.Spec.Ports {
+				labels := map[string]string{
+					"__meta_kube_service_name":          svc.Name,
+					"__meta_kube_service_namespace":     svc.Namespace,
+					"__meta_kube_service_uid":           string(svc.UID),
+					"__meta_kube_service_port_name":     port.Name,
+					"__meta_kube_service_port_protocol": string(port.Protocol),
+				}
+				sd.addMetaLabels(labels, svc)
+
+				groups = append(groups, sdTargetGroup{
+					Targets: []string{fmt.Sprintf("%s:%d", host, port.Port)},
+					Labels:  labels,
+				})
+			}
+		}
+	}
+
+	return groups
+}
+
+func (sd *serviceDiscovery) addMetaLabels(labels map[string]string, svc *v1.Service) {
+	for _, key := range sd.labelKeys {
+		if v, ok := svc.Labels[key]; ok {
+			labels["meta_labels_"+sanitizeLabelName(key)] = v
+		}
+	}
+	for _, key := range sd.annotationKeys {
+		if v, ok := svc.Annotations[key]; ok {
+			labels["meta_annotations_"+sanitizeLabelName(key)] = v
+		}
+	}
+}
+
+// sanitizeLabelName replaces characters that aren't valid in a Prometheus
+// label name (e.g. "/" or "." in an annotation key) with underscores.
+func sanitizeLabelName(name string) string {
+	out := make([]rune, 0, len(name))
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,97 @@
+// Package server builds the *http.Server that serves /metrics (and
+// friends), wiring up optional TLS and delegated bearer-token
+// authentication/authorization.
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/jhorwit2/kube-svc-metrics/auth"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Config holds the flag-derived settings needed to stand up the metrics
+// HTTP(S) server.
+type Config struct {
+	ListenAddress string
+
+	TLSCertFile  string
+	TLSKeyFile   string
+	ClientCAFile string
+
+	AuthTokenFile string
+	AuthClient    kubernetes.Interface // built from --auth-kubeconfig, or the main clientset when another auth option is set; nil to disable delegated TokenReview/SAR auth
+}
+
+// New builds an *http.Server serving handler according to cfg, populating
+// TLSConfig when cfg configures TLS. Callers are responsible for wrapping
+// handler with WrapAuth first where authentication is desired — New itself
+// applies no auth, since not every path a caller mounts (e.g. liveness
+// probes) should require it.
+func New(cfg Config, handler http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:    cfg.ListenAddress,
+		Handler: handler,
+	}
+
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		return server, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.ClientCAFile != "" {
+		caCert, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	server.TLSConfig = tlsConfig
+
+	return server, nil
+}
+
+// ListenAndServe serves cfg's configured protocol: plain HTTP, or HTTPS
+// when a TLS cert/key pair was configured.
+func ListenAndServe(server *http.Server, cfg Config) error {
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return server.ListenAndServe()
+}
+
+// WrapAuth wraps handler with bearer-token authentication/authorization when
+// cfg configures an auth option, authorizing each request against its own
+// URL path rather than a fixed resource name. With no auth option
+// configured, handler is returned unchanged. Callers should apply this only
+// to the paths that need protecting (e.g. /metrics, /sd) and leave liveness
+// probes like /healthz and /readyz unwrapped.
+func WrapAuth(cfg Config, handler http.Handler) (http.Handler, error) {
+	var staticTokens map[string]authenticationv1.UserInfo
+	if cfg.AuthTokenFile != "" {
+		var err error
+		staticTokens, err = auth.LoadStaticTokens(cfg.AuthTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading auth token file: %w", err)
+		}
+	}
+
+	if staticTokens == nil && cfg.AuthClient == nil {
+		return handler, nil
+	}
+
+	return auth.Middleware(auth.Config{
+		Client:       cfg.AuthClient,
+		StaticTokens: staticTokens,
+	})(handler), nil
+}
@@ -0,0 +1,32 @@
+// Package signals provides a stop channel that closes on SIGTERM/SIGINT, so
+// informers and servers can shut down gracefully instead of running forever.
+package signals
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var onlyOneSignalHandler = make(chan struct{})
+
+// SetupSignalHandler returns a channel that is closed on the first SIGTERM
+// or SIGINT. On a second signal the process exits immediately with a
+// non-zero status, so an operator can force-kill a hung shutdown.
+//
+// It panics when called more than once.
+func SetupSignalHandler() <-chan struct{} {
+	close(onlyOneSignalHandler) // panics when called twice
+
+	stop := make(chan struct{})
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-c
+		close(stop)
+		<-c
+		os.Exit(1) // second signal, force exit
+	}()
+
+	return stop
+}